@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:       "completion [bash|zsh|fish]",
+	Short:     "Generate a shell completion script",
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Args:      cobra.ExactValidArgs(1),
+	Long: `completion prints a shell completion script for defluff to stdout.
+
+To load it in your current shell:
+
+  source <(defluff completion bash)
+
+To install it permanently, redirect it into your shell's completion
+directory, e.g. /etc/bash_completion.d/defluff for bash.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		default:
+			return fmt.Errorf("unsupported shell %q", args[0])
+		}
+	},
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile is a named, reusable bundle of filter settings, selected
+// via --profile or DEFLUFF_PROFILE so operators don't have to repeat
+// a long flag incantation per cluster or component.
+type Profile struct {
+	Include       []string `yaml:"include"`
+	Exclude       []string `yaml:"exclude"`
+	Color         string   `yaml:"color"`
+	MinLevel      string   `yaml:"minLevel"`
+	OnlyLevel     []string `yaml:"onlyLevel"`
+	DropUnleveled bool     `yaml:"dropUnleveled"`
+	TimeFormat    string   `yaml:"timeFormat"`
+	Singleline    bool     `yaml:"singleline"`
+}
+
+// Config is the top-level shape of a defluff.yaml config file.
+type Config struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// configSearchPaths lists the locations checked, in order, when
+// --config isn't given explicitly.
+func configSearchPaths() []string {
+	paths := []string{"defluff.yaml"}
+
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "defluff", "config.yaml"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "defluff", "config.yaml"))
+	}
+
+	return append(paths, "/etc/defluff/config.yaml")
+}
+
+// loadConfig reads and parses the config file at explicitPath, or the
+// first of configSearchPaths that exists. It's not an error for no
+// config file to be found; callers get a Config with no profiles.
+func loadConfig(explicitPath string) (*Config, error) {
+	path := explicitPath
+	if path == "" {
+		for _, candidate := range configSearchPaths() {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+
+	if path == "" {
+		return &Config{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// resolveProfile looks up name in cfg.Profiles, falling back to
+// DEFLUFF_PROFILE when name is empty. No profile requested (name and
+// DEFLUFF_PROFILE both empty) yields the zero Profile, not an error,
+// so defluff keeps working with no config at all. A name that was
+// explicitly given but isn't in cfg.Profiles is an error instead of a
+// silent fallback, so a typo'd --profile or stale DEFLUFF_PROFILE
+// doesn't quietly run with unfiltered, uncolored defaults.
+func resolveProfile(cfg *Config, name string) (Profile, error) {
+	if name == "" {
+		name = os.Getenv("DEFLUFF_PROFILE")
+	}
+	if name == "" {
+		return Profile{}, nil
+	}
+	prof, ok := cfg.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile %q not found in config", name)
+	}
+	return prof, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// discardStdout redirects os.Stdout to /dev/null for the duration of
+// a benchmark, restoring it on return, so timing measures formatting
+// and flattening work rather than terminal I/O.
+func discardStdout(b *testing.B) {
+	b.Helper()
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	old := os.Stdout
+	os.Stdout = devNull
+	b.Cleanup(func() {
+		os.Stdout = old
+		devNull.Close()
+	})
+}
+
+const benchBPFDaemonLine = `{"ts":"2024-01-01T00:00:00Z","level":"info","logger":"controller","msg":"reconciled bpfProgram","spec":{"containers":[{"name":"a","image":"img-a"},{"name":"b","image":"img-b"}]},"tags":["x","y","z"],"count":3}`
+
+const benchOperatorLine = `2024-01-01T00:00:00Z INFO controller {"severity":"INFO","msg":"reconciled bpfProgram","spec":{"containers":[{"name":"a","image":"img-a"},{"name":"b","image":"img-b"}]},"tags":["x","y","z"],"count":3}`
+
+// BenchmarkProcessBPFDaemonLog and BenchmarkProcessBPFDaemonLogFast
+// (and their operator-log counterparts below) measure the slow
+// (encoding/json) and --fast (jsonparser) paths against the same
+// input, in single-line mode. Allocations drop substantially under
+// --fast (roughly a third of the slow path's), but measured
+// throughput is only ~1.8x for bpfdaemon-style lines and ~1.2x for
+// operator-style lines, short of the >3x originally hoped for -
+// don't cite a bigger number without rerunning these.
+func BenchmarkProcessBPFDaemonLog(b *testing.B) {
+	discardStdout(b)
+	opts := &options{singleline: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := processLogLine(benchBPFDaemonLine, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessBPFDaemonLogFast(b *testing.B) {
+	discardStdout(b)
+	opts := &options{singleline: true, fast: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := processLogLine(benchBPFDaemonLine, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessOperatorLog(b *testing.B) {
+	discardStdout(b)
+	opts := &options{singleline: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := processLogLine(benchOperatorLine, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessOperatorLogFast(b *testing.B) {
+	discardStdout(b)
+	opts := &options{singleline: true, fast: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := processLogLine(benchOperatorLine, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import "testing"
+
+func TestMatchFieldPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"spec.containers.image", "spec.containers[0].image", true},
+		{"spec.containers.image", "spec.containers[1].image", true},
+		{"spec.containers.image", "spec.containers[0].name", false},
+		{"spec.*.image", "spec.containers[0].image", true},
+		{"spec.*.image", "spec.volumes[0].image", true},
+		{"**.image", "spec.containers[0].image", true},
+		{"**.image", "image", true},
+		{"**", "anything.at.all", true},
+		{"spec.containers[]", "spec.containers[0]", true},
+		{"msg", "msg", true},
+		{"msg", "message", false},
+	}
+
+	for _, tc := range tests {
+		if got := matchFieldPath(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("matchFieldPath(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeLevel(t *testing.T) {
+	tests := []struct {
+		raw       string
+		wantLevel string
+		wantOK    bool
+	}{
+		{"info", "info", true},
+		{"INFO", "info", true},
+		{"  warn  ", "warn", true},
+		{"warning", "warn", true},
+		{"wrn", "warn", true},
+		{"err", "error", true},
+		{"crit", "critical", true},
+		{"panic", "critical", true},
+		{"", "", false},
+		{"nonsense", "", false},
+	}
+
+	for _, tc := range tests {
+		level, ok := normalizeLevel(tc.raw)
+		if level != tc.wantLevel || ok != tc.wantOK {
+			t.Errorf("normalizeLevel(%q) = (%q, %v), want (%q, %v)", tc.raw, level, ok, tc.wantLevel, tc.wantOK)
+		}
+	}
+}
+
+func TestPassesLevelFilter(t *testing.T) {
+	tests := []struct {
+		name          string
+		level         string
+		found         bool
+		onlyLevel     []string
+		minLevel      string
+		dropUnleveled bool
+		want          bool
+	}{
+		{"unleveled passes by default", "", false, nil, "", false, true},
+		{"unleveled dropped when requested", "", false, nil, "", true, false},
+		{"only-level keeps a match", "warn", true, []string{"warn", "error"}, "", false, true},
+		{"only-level drops a non-match", "info", true, []string{"warn", "error"}, "", false, false},
+		{"min-level keeps at threshold", "warn", true, nil, "warn", false, true},
+		{"min-level keeps above threshold", "error", true, nil, "warn", false, true},
+		{"min-level drops below threshold", "info", true, nil, "warn", false, false},
+		{"unrecognized min-level never filters", "info", true, nil, "bogus", false, true},
+		{"no filters keeps everything", "info", true, nil, "", false, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := passesLevelFilter(tc.level, tc.found, tc.onlyLevel, tc.minLevel, tc.dropUnleveled)
+			if got != tc.want {
+				t.Errorf("passesLevelFilter(%q, %v, %v, %q, %v) = %v, want %v",
+					tc.level, tc.found, tc.onlyLevel, tc.minLevel, tc.dropUnleveled, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	const layout = "01-02|15:04:05.000"
+
+	tests := []struct {
+		name string
+		ts   string
+		want string
+	}{
+		{"empty passes through", "", ""},
+		{"RFC3339Nano reformats", "2024-01-02T15:04:05.123456789Z", "01-02|15:04:05.123"},
+		{"unix nanos reformats", "1704207845000000000", "01-02|15:04:05.000"},
+		{"unparseable passes through unchanged", "not-a-timestamp", "not-a-timestamp"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatTimestamp(tc.ts, layout); got != tc.want {
+				t.Errorf("formatTimestamp(%q, %q) = %q, want %q", tc.ts, layout, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDetectLevelKeepsUnrecognizedRawValue(t *testing.T) {
+	data := map[string]interface{}{
+		"level": "NOTICE",
+		"msg":   "hi",
+	}
+
+	level, found := detectLevel(data)
+	if !found {
+		t.Fatalf("detectLevel() found = false, want true")
+	}
+	if level != "NOTICE" {
+		t.Errorf(`detectLevel() level = %q, want "NOTICE" (raw value preserved, not discarded)`, level)
+	}
+	if _, present := data["level"]; present {
+		t.Errorf("detectLevel() left the level key in data: %v", data)
+	}
+}
+
+func TestFlattenMapWithArraysIndexesArrays(t *testing.T) {
+	data := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "a", "image": "img-a"},
+				map[string]interface{}{"name": "b", "image": "img-b"},
+			},
+		},
+		"tags": []interface{}{"x", "y"},
+	}
+
+	flat := flattenMapWithArrays(data, "")
+
+	want := map[string]interface{}{
+		"spec.containers[0].name":  "a",
+		"spec.containers[0].image": "img-a",
+		"spec.containers[1].name":  "b",
+		"spec.containers[1].image": "img-b",
+		"tags[0]":                  "x",
+		"tags[1]":                  "y",
+	}
+	if len(flat) != len(want) {
+		t.Fatalf("flattenMapWithArrays returned %d paths, want %d: %v", len(flat), len(want), flat)
+	}
+	for k, v := range want {
+		if flat[k] != v {
+			t.Errorf("flattenMapWithArrays()[%q] = %v, want %v", k, flat[k], v)
+		}
+	}
+}
+
+func TestApplyFieldMaskReachesArrayElements(t *testing.T) {
+	flat := flattenMapWithArrays(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "a", "image": "img-a"},
+				map[string]interface{}{"name": "b", "image": "img-b"},
+			},
+		},
+	}, "")
+
+	masked := applyFieldMask(flat, []string{"spec.containers.image"}, nil)
+
+	if len(masked) != 2 {
+		t.Fatalf("applyFieldMask kept %d fields, want 2: %v", len(masked), masked)
+	}
+	if masked["spec.containers[0].image"] != "img-a" || masked["spec.containers[1].image"] != "img-b" {
+		t.Errorf("applyFieldMask() = %v, want only the two image fields", masked)
+	}
+}
+
+func TestCollapseArraysForDisplay(t *testing.T) {
+	flat := map[string]interface{}{
+		"tags[0]":                  "x",
+		"tags[1]":                  "y",
+		"spec.containers[0].image": "img-a",
+	}
+
+	display := collapseArraysForDisplay(flat)
+
+	if display["tags"] != "[x, y]" {
+		t.Errorf(`collapseArraysForDisplay()["tags"] = %v, want "[x, y]"`, display["tags"])
+	}
+	if display["spec.containers[0].image"] != "img-a" {
+		t.Errorf("collapseArraysForDisplay dropped or changed a non-array-leaf path: %v", display)
+	}
+	if _, ok := display["tags[0]"]; ok {
+		t.Errorf("collapseArraysForDisplay left the per-index key %q in place", "tags[0]")
+	}
+}
@@ -0,0 +1,16 @@
+// Defluff is a command-line tool that makes the dense, single-line
+// JSON log entries produced by the bpfman operator and daemon
+// readable. See `defluff filter -h` for the main behavior, and
+// `defluff -h` for the full set of subcommands (schema, completion,
+// man).
+package main
+
+import (
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	signal.Ignore(syscall.SIGPIPE)
+	Execute()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var manCmd = &cobra.Command{
+	Use:   "man [output-dir]",
+	Short: "Generate man pages for defluff and its subcommands",
+	Args:  cobra.MaximumNArgs(1),
+	Long: `man writes a man page for defluff and each of its subcommands into
+output-dir (default "artifacts/man"), creating it if needed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "artifacts/man"
+		if len(args) == 1 {
+			dir = args[0]
+		}
+
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+
+		header := &doc.GenManHeader{
+			Title:   "DEFLUFF",
+			Section: "1",
+		}
+		return doc.GenManTree(rootCmd, header, dir)
+	},
+}
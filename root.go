@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// cfgFile and profileName are bound to persistent flags in init() so
+// every subcommand (not just filter) can honor --config/--profile.
+var (
+	cfgFile     string
+	profileName string
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "defluff",
+	Short: "Make bpfman operator and daemon JSON logs readable",
+	Long: `Defluff reformats the dense, single-line JSON log entries produced by
+the bpfman operator and daemon into something a human can scan.
+
+Running "defluff" with no subcommand is equivalent to "defluff filter".`,
+	// Execute below prints the returned error itself; silence cobra's
+	// own "Error: ..." print and usage dump so it isn't shown twice.
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runFilter(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default searches ./defluff.yaml, $XDG_CONFIG_HOME/defluff/config.yaml, /etc/defluff/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "named profile from the config file to use (env DEFLUFF_PROFILE)")
+
+	rootCmd.AddCommand(filterCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(completionCmd)
+	rootCmd.AddCommand(manCmd)
+}
+
+// Execute runs the cobra command tree; it's the sole entry point
+// called from main().
+func Execute() {
+	// The root command falls back to filter's behavior, so it needs
+	// filter's flags too. Done here, rather than in init(), so it
+	// doesn't depend on init() ordering between this file and
+	// filter.go.
+	rootCmd.Flags().AddFlagSet(filterCmd.Flags())
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the set of field paths seen on stdin, with their JSON type",
+	Long: `schema reads JSON log lines from stdin the same way filter does, but
+instead of reformatting each line, it accumulates every dotted field
+path it sees (including nested and array fields) along with its JSON
+type, then prints the sorted result once input ends.
+
+This is useful for building an include/exclude field list or a config
+profile for a log source you haven't worked with before.`,
+	RunE: runSchema,
+}
+
+// schemaType reports the JSON type name for a flattened field value,
+// as produced by flattenMapWithArrays.
+func schemaType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case nil:
+		return "null"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	fields := make(map[string]string)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		jsonPart := line
+		if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+			parts := strings.SplitN(line, "{", 2)
+			if len(parts) < 2 {
+				continue
+			}
+			jsonPart = "{" + parts[1]
+		}
+
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(jsonPart), &data); err != nil {
+			continue
+		}
+
+		for path, value := range flattenMapWithArrays(data, "") {
+			fields[path] = schemaType(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	out := cmd.OutOrStdout()
+	for _, path := range paths {
+		fmt.Fprintf(out, "%s: %s\n", path, fields[path])
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+//go:build ignore
+
+// generate_artifacts.go builds defluff and uses its own "completion"
+// and "man" subcommands to (re)generate the shell completions and man
+// pages checked into artifacts/.
+//
+// Run it with: go run tools/generate_artifacts.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const artifactsDir = "artifacts"
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "generate_artifacts:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	bin, err := buildDefluff()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(bin)
+
+	completionsDir := filepath.Join(artifactsDir, "completions")
+	if err := os.MkdirAll(completionsDir, 0o755); err != nil {
+		return err
+	}
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		out, err := exec.Command(bin, "completion", shell).Output()
+		if err != nil {
+			return fmt.Errorf("generating %s completion: %w", shell, err)
+		}
+		dest := filepath.Join(completionsDir, "defluff."+shell)
+		if err := os.WriteFile(dest, out, 0o644); err != nil {
+			return err
+		}
+	}
+
+	manDir := filepath.Join(artifactsDir, "man")
+	if out, err := exec.Command(bin, "man", manDir).CombinedOutput(); err != nil {
+		return fmt.Errorf("generating man pages: %w: %s", err, out)
+	}
+
+	return nil
+}
+
+// buildDefluff compiles the defluff binary from the module root into
+// a temporary path and returns it.
+func buildDefluff() (string, error) {
+	bin := filepath.Join(os.TempDir(), "defluff-artifacts-gen")
+
+	cmd := exec.Command("go", "build", "-o", bin, ".")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("building defluff: %w", err)
+	}
+	return bin, nil
+}